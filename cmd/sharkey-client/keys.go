@@ -0,0 +1,114 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// loadOrCreateKey reads a private key from path, generating and persisting a
+// new one of the given type (rsa, ecdsa, ed25519) if none exists yet. The
+// returned crypto.Signer is handed to ssh.NewSignerFromSigner by the caller.
+func loadOrCreateKey(path string, keyType string) (crypto.Signer, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		return parsePEMKey(data)
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	key, pemBlock, err := generateKey(keyType)
+	if err != nil {
+		return nil, fmt.Errorf("generating %s key: %w", keyType, err)
+	}
+
+	if err := os.WriteFile(path, pem.EncodeToMemory(pemBlock), 0600); err != nil {
+		return nil, fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	return key, nil
+}
+
+func parsePEMKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("key is not a crypto.Signer")
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
+
+func generateKey(keyType string) (crypto.Signer, *pem.Block, error) {
+	switch keyType {
+	case "rsa":
+		key, err := rsa.GenerateKey(rand.Reader, 3072)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}, nil
+
+	case "ecdsa":
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, nil
+
+	case "ed25519":
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported key type %q, want rsa, ecdsa, or ed25519", keyType)
+	}
+}
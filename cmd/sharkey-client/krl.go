@@ -0,0 +1,108 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// runKRL fetches the server's current Key Revocation List and atomically
+// installs it at --out, for sshd's RevokedKeys directive to pick up:
+//
+//	# /etc/ssh/sshd_config
+//	RevokedKeys /etc/ssh/sharkey.krl
+//
+// Run this periodically (e.g. from cron or a systemd timer) alongside host
+// cert renewal; sshd re-reads RevokedKeys on every connection, no reload
+// needed.
+func runKRL(args []string) {
+	fs := flag.NewFlagSet("krl", flag.ExitOnError)
+	server := fs.String("server", "", "base URL of the sharkey server, e.g. https://sharkey.example.com")
+	out := fs.String("out", "/etc/ssh/sharkey.krl", "path to atomically install the KRL at")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *server == "" {
+		fmt.Fprintln(os.Stderr, "sharkey-client krl: --server is required")
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, *server+"/krl", nil)
+	if err != nil {
+		fatalf("building request: %v", err)
+	}
+	if etag, err := os.ReadFile(*out + ".etag"); err == nil {
+		req.Header.Set("If-None-Match", string(etag))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		fatalf("fetching KRL: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		fmt.Println("KRL unchanged")
+		return
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		fatalf("server returned %s: %s", resp.Status, body)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		fatalf("reading KRL response: %v", err)
+	}
+
+	if err := atomicWriteFile(*out, data, 0644); err != nil {
+		fatalf("installing KRL at %s: %v", *out, err)
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		_ = os.WriteFile(*out+".etag", []byte(etag), 0644)
+	}
+
+	fmt.Printf("installed KRL at %s (%d bytes)\n", *out, len(data))
+}
+
+// atomicWriteFile writes data to a temp file in the same directory as path
+// and renames it into place, so readers never observe a partial KRL.
+func atomicWriteFile(path string, data []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
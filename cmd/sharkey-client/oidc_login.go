@@ -0,0 +1,128 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"runtime"
+
+	"github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
+)
+
+// oidcLogin performs a browser-based OIDC login and returns the raw ID token
+// to send as a bearer token. It opens the user's browser to the provider's
+// authorization endpoint and receives the callback on a loopback listener.
+func oidcLogin(issuer string, clientID string) (string, error) {
+	ctx := context.Background()
+
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return "", fmt.Errorf("discovering provider %s: %w", issuer, err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("starting local callback listener: %w", err)
+	}
+	defer listener.Close()
+
+	redirectURL := fmt.Sprintf("http://127.0.0.1:%d/callback", listener.Addr().(*net.TCPAddr).Port)
+	oauthConf := oauth2.Config{
+		ClientID:    clientID,
+		RedirectURL: redirectURL,
+		Endpoint:    provider.Endpoint(),
+		Scopes:      []string{oidc.ScopeOpenID, "profile", "email", "groups"},
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return "", err
+	}
+
+	type result struct {
+		code string
+		err  error
+	}
+	done := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != state {
+			done <- result{err: fmt.Errorf("unexpected state %q in callback", got)}
+			http.Error(w, "login failed: state mismatch", http.StatusBadRequest)
+			return
+		}
+		if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+			done <- result{err: fmt.Errorf("authorization server returned error: %s", errMsg)}
+			http.Error(w, "login failed", http.StatusBadRequest)
+			return
+		}
+		done <- result{code: r.URL.Query().Get("code")}
+		fmt.Fprintln(w, "Login complete, you can close this tab.")
+	})
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(listener)
+	defer srv.Close()
+
+	authURL := oauthConf.AuthCodeURL(state)
+	fmt.Printf("Opening browser to log in:\n%s\n", authURL)
+	openBrowser(authURL)
+
+	res := <-done
+	if res.err != nil {
+		return "", res.err
+	}
+
+	token, err := oauthConf.Exchange(ctx, res.code)
+	if err != nil {
+		return "", fmt.Errorf("exchanging authorization code: %w", err)
+	}
+
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return "", fmt.Errorf("token response did not include an id_token")
+	}
+	return idToken, nil
+}
+
+func randomState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	_ = cmd.Start()
+}
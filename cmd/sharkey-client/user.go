@@ -0,0 +1,164 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func runUser(args []string) {
+	fs := flag.NewFlagSet("user", flag.ExitOnError)
+	server := fs.String("server", "", "base URL of the sharkey server, e.g. https://sharkey.example.com")
+	keyPath := fs.String("key", defaultKeyPath(), "path to the local user private key, created if missing")
+	keyType := fs.String("key-type", "ed25519", "key type to generate if --key doesn't exist: rsa, ecdsa, or ed25519")
+	validity := fs.Duration("validity", 0, "requested certificate validity, clamped to the server's configured maximum; server's configured default is used if zero")
+	oidcIssuer := fs.String("oidc-issuer", "", "OIDC issuer URL; if set, a browser login is performed to obtain a bearer token")
+	oidcClientID := fs.String("oidc-client-id", "", "OAuth2 client id to use for the browser login")
+	agentSock := fs.String("agent-sock", os.Getenv("SSH_AUTH_SOCK"), "path to the ssh-agent socket")
+	if err := fs.Parse(args); err != nil {
+		os.Exit(1)
+	}
+
+	if *server == "" {
+		fmt.Fprintln(os.Stderr, "sharkey-client user: --server is required")
+		os.Exit(1)
+	}
+
+	key, err := loadOrCreateKey(*keyPath, *keyType)
+	if err != nil {
+		fatalf("loading key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromSigner(key)
+	if err != nil {
+		fatalf("building ssh signer: %v", err)
+	}
+
+	var bearerToken string
+	if *oidcIssuer != "" {
+		bearerToken, err = oidcLogin(*oidcIssuer, *oidcClientID)
+		if err != nil {
+			fatalf("oidc login: %v", err)
+		}
+	}
+
+	certLine, err := fetchUserCert(*server, signer.PublicKey(), bearerToken, *validity)
+	if err != nil {
+		fatalf("fetching certificate: %v", err)
+	}
+
+	cert, err := parseCertificate(certLine)
+	if err != nil {
+		fatalf("parsing certificate: %v", err)
+	}
+
+	if *agentSock == "" {
+		fatalf("no ssh-agent socket found; pass --agent-sock or set SSH_AUTH_SOCK")
+	}
+	if err := addToAgent(*agentSock, key, cert); err != nil {
+		fatalf("loading certificate into ssh-agent: %v", err)
+	}
+
+	fmt.Printf("loaded certificate serial %d for %s into ssh-agent, valid until %s\n",
+		cert.Serial, cert.KeyId, time.Unix(int64(cert.ValidBefore), 0).Local())
+}
+
+// fetchUserCert POSTs the public key to the server's /enroll_user endpoint
+// and returns the raw "<type>-cert-v01@openssh.com <base64>\n" response line.
+func fetchUserCert(server string, pubkey ssh.PublicKey, bearerToken string, validity time.Duration) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, server+"/enroll_user", bytes.NewReader(ssh.MarshalAuthorizedKey(pubkey)))
+	if err != nil {
+		return "", err
+	}
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+	if validity > 0 {
+		q := req.URL.Query()
+		q.Set("validity", validity.String())
+		req.URL.RawQuery = q.Encode()
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("server returned %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return string(body), nil
+}
+
+func parseCertificate(line string) (*ssh.Certificate, error) {
+	pubkey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := pubkey.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("server response was not a certificate")
+	}
+	return cert, nil
+}
+
+func addToAgent(sockPath string, key crypto.Signer, cert *ssh.Certificate) error {
+	conn, err := net.Dial("unix", sockPath)
+	if err != nil {
+		return fmt.Errorf("connecting to ssh-agent at %s: %w", sockPath, err)
+	}
+	defer conn.Close()
+
+	a := agent.NewClient(conn)
+	lifetime := cert.ValidBefore - cert.ValidAfter
+
+	return a.Add(agent.AddedKey{
+		PrivateKey:   key,
+		Certificate:  cert,
+		LifetimeSecs: uint32(lifetime),
+		Comment:      cert.KeyId,
+	})
+}
+
+func defaultKeyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "sharkey_id"
+	}
+	return filepath.Join(home, ".ssh", "sharkey_id")
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "sharkey-client: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,54 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"github.com/square/sharkey/pkg/server/config"
+	"github.com/square/sharkey/pkg/server/storage"
+
+	"github.com/coreos/go-oidc"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// Api holds the dependencies needed to serve certificate issuance requests.
+type Api struct {
+	conf    *config.Config
+	storage storage.Storage
+	signer  ssh.Signer
+	logger  *logrus.Logger
+
+	// oidcVerifier validates bearer tokens against the configured IdP's JWKS.
+	// It's nil when config.OIDC isn't set, in which case oidcAuthenticated
+	// always fails closed.
+	oidcVerifier *oidc.IDTokenVerifier
+
+	// krlCache holds the most recently generated Key Revocation List.
+	krlCache krlCache
+}
+
+// New builds an Api ready to be wired into an HTTP router. oidcVerifier may
+// be nil if config.OIDC isn't set.
+func New(conf *config.Config, store storage.Storage, signer ssh.Signer, logger *logrus.Logger, oidcVerifier *oidc.IDTokenVerifier) *Api {
+	return &Api{
+		conf:         conf,
+		storage:      store,
+		signer:       signer,
+		logger:       logger,
+		oidcVerifier: oidcVerifier,
+	}
+}
@@ -0,0 +1,62 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// TestAuditIssuanceFingerprintFormat checks the logged fingerprint matches
+// OpenSSH's SHA256:<unpadded base64> convention (as printed by
+// ssh-keygen -lf), not hex, so operators can correlate the two.
+func TestAuditIssuanceFingerprintFormat(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("building ssh public key: %v", err)
+	}
+
+	cert := &ssh.Certificate{Key: sshPub}
+	want := ssh.FingerprintSHA256(sshPub)
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(&buf)
+
+	auditIssuance(logger, "requester", cert)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decoding log entry: %v", err)
+	}
+
+	got, _ := entry["fingerprint"].(string)
+	if got != want {
+		t.Errorf("fingerprint = %q, want %q (ssh.FingerprintSHA256's format)", got, want)
+	}
+}
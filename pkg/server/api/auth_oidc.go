@@ -0,0 +1,143 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+const defaultUsernameClaim = "sub"
+const defaultGroupsClaim = "groups"
+
+// oidcClaims is the subset of an ID token's claims sharkey cares about. Which
+// JSON keys username/groups are read from is configurable via
+// config.OIDC.UsernameClaim/GroupsClaim.
+type oidcClaims struct {
+	username string
+	groups   []string
+}
+
+// oidcAuthenticated validates a bearer token from the Authorization header
+// against the discovered JWKS for c.conf.OIDC, checking exp/aud/iss, and
+// returns the username and group membership carried in its claims.
+func (c *Api) oidcAuthenticated(w http.ResponseWriter, r *http.Request) (oidcClaims, bool) {
+	oc := c.conf.OIDC
+	if oc == nil || c.oidcVerifier == nil {
+		logHttpError(r, w, errors.New("oidc authentication is not configured"), http.StatusNotFound, c.logger)
+		return oidcClaims{}, false
+	}
+
+	rawToken, err := bearerToken(r)
+	if err != nil {
+		logHttpError(r, w, err, http.StatusUnauthorized, c.logger)
+		return oidcClaims{}, false
+	}
+
+	idToken, err := c.oidcVerifier.Verify(context.Background(), rawToken)
+	if err != nil {
+		logHttpError(r, w, fmt.Errorf("verifying bearer token: %w", err), http.StatusUnauthorized, c.logger)
+		return oidcClaims{}, false
+	}
+
+	if !audienceAllowed(idToken.Audience, oc.AllowedAudiences) {
+		logHttpError(r, w, errors.New("token audience not allowed"), http.StatusUnauthorized, c.logger)
+		return oidcClaims{}, false
+	}
+
+	usernameClaim := oc.UsernameClaim
+	if usernameClaim == "" {
+		usernameClaim = defaultUsernameClaim
+	}
+	groupsClaim := oc.GroupsClaim
+	if groupsClaim == "" {
+		groupsClaim = defaultGroupsClaim
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		logHttpError(r, w, fmt.Errorf("decoding token claims: %w", err), http.StatusUnauthorized, c.logger)
+		return oidcClaims{}, false
+	}
+
+	username, _ := claims[usernameClaim].(string)
+	if username == "" {
+		logHttpError(r, w, errors.New("token missing username claim"), http.StatusUnauthorized, c.logger)
+		return oidcClaims{}, false
+	}
+
+	var groups []string
+	if raw, ok := claims[groupsClaim].([]interface{}); ok {
+		for _, g := range raw {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+
+	return oidcClaims{username: username, groups: groups}, true
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	auth := r.Header.Get("Authorization")
+	if auth == "" {
+		return "", errors.New("no Authorization header supplied")
+	}
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return "", errors.New("Authorization header is not a bearer token")
+	}
+	return strings.TrimPrefix(auth, prefix), nil
+}
+
+// audienceAllowed reports whether tokenAudience intersects allowed. allowed
+// is expected to be non-empty: oidc.NewVerifier refuses to build a verifier
+// without at least one configured audience, so this never silently accepts
+// a token meant for an unrelated client.
+func audienceAllowed(tokenAudience []string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return false
+	}
+	for _, want := range allowed {
+		for _, got := range tokenAudience {
+			if want == got {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// groupPermissions aggregates the principals and extensions granted by every
+// group config.Groups also grants the user, so membership in more than one
+// group is additive.
+func groupPermissions(cfg *config.Config, groups []string) (principals []string, extensions []string) {
+	for _, g := range groups {
+		perm, ok := cfg.Groups[g]
+		if !ok {
+			continue
+		}
+		principals = append(principals, perm.Principals...)
+		extensions = append(extensions, perm.Extensions...)
+	}
+	return principals, extensions
+}
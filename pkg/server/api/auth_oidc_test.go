@@ -0,0 +1,40 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import "testing"
+
+func TestAudienceAllowed(t *testing.T) {
+	cases := []struct {
+		name      string
+		token     []string
+		allowed   []string
+		wantAllow bool
+	}{
+		{"matching audience", []string{"sharkey"}, []string{"sharkey"}, true},
+		{"non-matching audience", []string{"some-other-client"}, []string{"sharkey"}, false},
+		{"no allowed audiences configured fails closed", []string{"sharkey"}, nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := audienceAllowed(tc.token, tc.allowed); got != tc.wantAllow {
+				t.Errorf("audienceAllowed(%v, %v) = %v, want %v", tc.token, tc.allowed, got, tc.wantAllow)
+			}
+		})
+	}
+}
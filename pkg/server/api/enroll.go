@@ -86,12 +86,14 @@ func encodeCert(certificate *ssh.Certificate) (string, error) {
 func (c *Api) EnrollHost(hostname string, r *http.Request) (string, error) {
 	pubkey, err := readPubkey(r)
 	if err != nil {
+		errorTotal.WithLabelValues(certTypeLabel(ssh.HostCert), string(errClassBadPubkey)).Inc()
 		return "", err
 	}
 
 	// Update table with host
 	id, err := c.storage.RecordIssuance(ssh.HostCert, hostname, pubkey)
 	if err != nil {
+		errorTotal.WithLabelValues(certTypeLabel(ssh.HostCert), string(errClassStorageFailure)).Inc()
 		return "", err
 	}
 
@@ -100,6 +102,7 @@ func (c *Api) EnrollHost(hostname string, r *http.Request) (string, error) {
 		return "", err
 	}
 
+	auditIssuance(c.logger, hostname, signedCert)
 	return encodeCert(signedCert)
 }
 
@@ -117,6 +120,10 @@ func clientHostnameMatches(hostname string, r *http.Request) bool {
 }
 
 func (c *Api) signHost(hostname string, serial uint64, pubkey ssh.PublicKey) (*ssh.Certificate, error) {
+	if role, ok := c.roleForHost(hostname); ok {
+		return c.signWithRole(hostname, role, roleTemplateData{Hostname: hostname}, serial, ssh.HostCert, pubkey)
+	}
+
 	principals := []string{hostname}
 	if c.conf.StripSuffix != "" && strings.HasSuffix(hostname, c.conf.StripSuffix) {
 		principals = append(principals, strings.TrimSuffix(hostname, c.conf.StripSuffix))
@@ -124,21 +131,60 @@ func (c *Api) signHost(hostname string, serial uint64, pubkey ssh.PublicKey) (*s
 	if aliases, ok := c.conf.Aliases[hostname]; ok {
 		principals = append(principals, aliases...)
 	}
-	return c.sign(hostname, principals, serial, ssh.HostCert, pubkey)
+	return c.sign(hostname, principals, nil, nil, nil, serial, ssh.HostCert, pubkey, true)
+}
+
+// signWithRole renders role's principals against data and signs a
+// certificate using role's extensions, critical options, and validity
+// window in place of the server's global cert settings.
+func (c *Api) signWithRole(keyId string, role config.Role, data roleTemplateData, serial uint64, certType uint32, pubkey ssh.PublicKey) (*ssh.Certificate, error) {
+	principals, err := renderPrincipals(role, data)
+	if err != nil {
+		return nil, err
+	}
+	validAfterOffset, durationOverride, err := roleValidity(role)
+	if err != nil {
+		return nil, err
+	}
+	return c.sign(keyId, principals, roleExtensions(role), role.CriticalOptions, &signValidity{validAfterOffset, durationOverride}, serial, certType, pubkey, false)
+}
+
+// signValidity overrides the default "now, for the configured duration"
+// validity window sign otherwise uses.
+type signValidity struct {
+	validAfterOffset time.Duration
+	duration         *time.Duration
 }
 
-func (c *Api) sign(keyId string, principals []string, serial uint64, certType uint32, pubkey ssh.PublicKey) (*ssh.Certificate, error) {
+// useGlobalExtensions controls whether cfg.UserCertExtensions is layered
+// onto the cert in addition to extraExtensions: role-driven certs set this
+// to false so a role is the sole source of truth for what it grants,
+// letting a restrictive role (e.g. a bastion) omit an extension the global
+// config grants everyone else.
+func (c *Api) sign(keyId string, principals []string, extraExtensions []string, extraCriticalOptions map[string]string, validity *signValidity, serial uint64, certType uint32, pubkey ssh.PublicKey, useGlobalExtensions bool) (*ssh.Certificate, error) {
+	signStart := time.Now()
+
 	nonce := make([]byte, 32)
 	_, err := rand.Read(nonce)
 	if err != nil {
+		observeSignResult(certType, time.Since(signStart).Seconds(), err, errClassSignFailure)
 		return nil, err
 	}
+
 	startTime := time.Now()
 	duration, err := getDurationForCertType(c.conf, certType)
 	if err != nil {
+		observeSignResult(certType, time.Since(signStart).Seconds(), err, errClassSignFailure)
 		return nil, err
 	}
+	if validity != nil {
+		startTime = startTime.Add(validity.validAfterOffset)
+		if validity.duration != nil {
+			duration = *validity.duration
+		}
+	}
 	endTime := startTime.Add(duration)
+
 	template := ssh.Certificate{
 		Nonce:           nonce,
 		Key:             pubkey,
@@ -148,13 +194,15 @@ func (c *Api) sign(keyId string, principals []string, serial uint64, certType ui
 		ValidPrincipals: principals,
 		ValidAfter:      (uint64)(startTime.Unix()),
 		ValidBefore:     (uint64)(endTime.Unix()),
-		Permissions:     getPermissionsForCertType(&c.conf.SSH, certType),
+		Permissions:     getPermissionsForCertType(&c.conf.SSH, certType, extraExtensions, extraCriticalOptions, useGlobalExtensions),
 	}
 
 	err = template.SignCert(rand.Reader, c.signer)
 	if err != nil {
+		observeSignResult(certType, time.Since(signStart).Seconds(), err, errClassSignFailure)
 		return nil, err
 	}
+	observeSignResult(certType, time.Since(signStart).Seconds(), nil, "")
 	return &template, nil
 }
 
@@ -183,25 +231,62 @@ func proxyAuthenticated(ap *config.AuthenticatingProxy, w http.ResponseWriter, r
 }
 
 func (c *Api) EnrollUser(w http.ResponseWriter, r *http.Request) {
-	user, ok := proxyAuthenticated(c.conf.AuthenticatingProxy, w, r, c.logger)
+	var user string
+	var groups []string
+	var ok bool
+
+	if c.conf.OIDC != nil && r.Header.Get("Authorization") != "" {
+		var claims oidcClaims
+		claims, ok = c.oidcAuthenticated(w, r)
+		user, groups = claims.username, claims.groups
+	} else {
+		user, ok = proxyAuthenticated(c.conf.AuthenticatingProxy, w, r, c.logger)
+	}
 	if !ok {
-		// proxyAuthenticated sets http status & logs message
+		// oidcAuthenticated/proxyAuthenticated set http status & log a message
+		errorTotal.WithLabelValues(certTypeLabel(ssh.UserCert), string(errClassAuthFailure)).Inc()
 		return
 	}
 
 	pk, err := readPubkey(r)
 	if err != nil {
+		errorTotal.WithLabelValues(certTypeLabel(ssh.UserCert), string(errClassBadPubkey)).Inc()
 		logHttpError(r, w, err, http.StatusBadRequest, c.logger)
 		return
 	}
 
 	id, err := c.storage.RecordIssuance(ssh.UserCert, user, pk)
 	if err != nil {
+		errorTotal.WithLabelValues(certTypeLabel(ssh.UserCert), string(errClassStorageFailure)).Inc()
 		logHttpError(r, w, err, http.StatusInternalServerError, c.logger)
 		return
 	}
 
-	certificate, err := c.sign(user, []string{user}, id, ssh.UserCert, pk)
+	role, roleOk, err := c.roleForUser(r.URL.Query().Get("role"), user, groups)
+	if err != nil {
+		errorTotal.WithLabelValues(certTypeLabel(ssh.UserCert), string(errClassAuthFailure)).Inc()
+		logHttpError(r, w, err, http.StatusForbidden, c.logger)
+		return
+	}
+
+	requestedValidity, err := parseRequestedValidity(c.conf, r.URL.Query().Get("validity"))
+	if err != nil {
+		errorTotal.WithLabelValues(certTypeLabel(ssh.UserCert), string(errClassBadRequest)).Inc()
+		logHttpError(r, w, err, http.StatusBadRequest, c.logger)
+		return
+	}
+
+	var certificate *ssh.Certificate
+	if roleOk {
+		// A role defines its own validity window, independently of any
+		// request-supplied one, the same way it's the sole source of its
+		// extensions (see useGlobalExtensions).
+		certificate, err = c.signWithRole(user, role, roleTemplateData{User: user}, id, ssh.UserCert, pk)
+	} else {
+		groupPrincipals, groupExtensions := groupPermissions(c.conf, groups)
+		principals := append([]string{user}, groupPrincipals...)
+		certificate, err = c.sign(user, principals, groupExtensions, nil, requestedValidity, id, ssh.UserCert, pk, true)
+	}
 	if err != nil {
 		logHttpError(r, w, err, http.StatusInternalServerError, c.logger)
 		return
@@ -214,6 +299,7 @@ func (c *Api) EnrollUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, _ = w.Write([]byte(certString))
+	auditIssuance(c.logger, user, certificate)
 
 	encodedPublicKey := base64.StdEncoding.EncodeToString(pk.Marshal())
 	c.logger.WithFields(logrus.Fields{
@@ -223,6 +309,39 @@ func (c *Api) EnrollUser(w http.ResponseWriter, r *http.Request) {
 	}).Println("call EnrollUser")
 }
 
+// parseRequestedValidity parses the sharkey-client --validity flag's
+// "validity" query parameter into a signValidity overriding sign's default
+// duration, clamped to cfg.MaxUserCertValidity. An empty raw value (the
+// common case) returns nil so sign falls back to UserCertDuration.
+// cfg.MaxUserCertValidity being unset means requests may never override the
+// default at all, rather than silently granting an unbounded certificate.
+func parseRequestedValidity(cfg *config.Config, raw string) (*signValidity, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	requested, err := time.ParseDuration(raw)
+	if err != nil {
+		return nil, fmt.Errorf("parsing validity %q: %w", raw, err)
+	}
+	if requested <= 0 {
+		return nil, fmt.Errorf("validity %q must be positive", raw)
+	}
+
+	if cfg.MaxUserCertValidity == "" {
+		return nil, fmt.Errorf("server does not allow overriding certificate validity")
+	}
+	max, err := time.ParseDuration(cfg.MaxUserCertValidity)
+	if err != nil {
+		return nil, fmt.Errorf("server misconfiguration: parsing max_user_cert_validity %q: %w", cfg.MaxUserCertValidity, err)
+	}
+	if requested > max {
+		requested = max
+	}
+
+	return &signValidity{duration: &requested}, nil
+}
+
 func getDurationForCertType(cfg *config.Config, certType uint32) (time.Duration, error) {
 	var duration time.Duration
 	var err error
@@ -239,14 +358,23 @@ func getDurationForCertType(cfg *config.Config, certType uint32) (time.Duration,
 	return duration, err
 }
 
-func getPermissionsForCertType(cfg *config.SSH, certType uint32) (perms ssh.Permissions) {
-	switch certType {
-	case ssh.UserCert:
-		if cfg != nil && len(cfg.UserCertExtensions) > 0 {
-			perms.Extensions = make(map[string]string, len(cfg.UserCertExtensions))
-			for _, ext := range cfg.UserCertExtensions {
-				perms.Extensions[ext] = ""
-			}
+func getPermissionsForCertType(cfg *config.SSH, certType uint32, extraExtensions []string, extraCriticalOptions map[string]string, useGlobalExtensions bool) (perms ssh.Permissions) {
+	var extensions []string
+	if certType == ssh.UserCert && cfg != nil && useGlobalExtensions {
+		extensions = append(extensions, cfg.UserCertExtensions...)
+	}
+	extensions = append(extensions, extraExtensions...)
+	if len(extensions) > 0 {
+		perms.Extensions = make(map[string]string, len(extensions))
+		for _, ext := range extensions {
+			perms.Extensions[ext] = ""
+		}
+	}
+
+	if len(extraCriticalOptions) > 0 {
+		perms.CriticalOptions = make(map[string]string, len(extraCriticalOptions))
+		for opt, value := range extraCriticalOptions {
+			perms.CriticalOptions[opt] = value
 		}
 	}
 	return
@@ -0,0 +1,80 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/square/sharkey/pkg/server/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGetPermissionsForCertTypeRoleOmitsGlobalExtensions(t *testing.T) {
+	cfg := &config.SSH{UserCertExtensions: []string{"permit-pty"}}
+
+	roleDriven := getPermissionsForCertType(cfg, ssh.UserCert, []string{"permit-port-forwarding"}, nil, false)
+	if _, ok := roleDriven.Extensions["permit-pty"]; ok {
+		t.Error("role-driven cert should not inherit the global UserCertExtensions")
+	}
+	if _, ok := roleDriven.Extensions["permit-port-forwarding"]; !ok {
+		t.Error("role-driven cert should still carry its own extensions")
+	}
+
+	global := getPermissionsForCertType(cfg, ssh.UserCert, nil, nil, true)
+	if _, ok := global.Extensions["permit-pty"]; !ok {
+		t.Error("non-role cert should still inherit the global UserCertExtensions")
+	}
+}
+
+func TestParseRequestedValidity(t *testing.T) {
+	cfgNoMax := &config.Config{}
+	cfgWithMax := &config.Config{MaxUserCertValidity: "24h"}
+
+	if v, err := parseRequestedValidity(cfgNoMax, ""); err != nil || v != nil {
+		t.Errorf("empty validity = (%v, %v), want (nil, nil)", v, err)
+	}
+
+	if _, err := parseRequestedValidity(cfgNoMax, "1h"); err == nil {
+		t.Error("expected an error requesting a validity override with no max_user_cert_validity configured")
+	}
+
+	if _, err := parseRequestedValidity(cfgWithMax, "not-a-duration"); err == nil {
+		t.Error("expected an error for an unparseable validity")
+	}
+
+	if _, err := parseRequestedValidity(cfgWithMax, "-1h"); err == nil {
+		t.Error("expected an error for a non-positive validity")
+	}
+
+	v, err := parseRequestedValidity(cfgWithMax, "1h")
+	if err != nil {
+		t.Fatalf("parseRequestedValidity: %v", err)
+	}
+	if v == nil || v.duration == nil || *v.duration != time.Hour {
+		t.Errorf("validity within the max = %v, want 1h", v)
+	}
+
+	v, err = parseRequestedValidity(cfgWithMax, "48h")
+	if err != nil {
+		t.Fatalf("parseRequestedValidity: %v", err)
+	}
+	if v == nil || v.duration == nil || *v.duration != 24*time.Hour {
+		t.Errorf("validity exceeding the max = %v, want clamped to 24h", v)
+	}
+}
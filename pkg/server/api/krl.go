@@ -0,0 +1,109 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/square/sharkey/pkg/server/krl"
+	"github.com/square/sharkey/pkg/server/storage"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// krlCache holds the most recently generated KRL so repeated /krl requests
+// between revocations don't rebuild the list on every poll. The cached KRL
+// carries no embedded signature (see krl.Generate).
+type krlCache struct {
+	mu          sync.Mutex
+	data        []byte
+	etag        string
+	generatedAt time.Time
+	forSerials  string // fingerprint of the revoked-serial set this was built from
+}
+
+// KRL handles GET /krl, streaming an OpenSSH Key Revocation List built from
+// every serial sharkey has revoked, scoped to the CA key. Hosts are
+// expected to poll this periodically and feed it to sshd's RevokedKeys.
+func (c *Api) KRL(w http.ResponseWriter, r *http.Request) {
+	revoked, err := c.storage.ListRevoked()
+	if err != nil {
+		logHttpError(r, w, err, http.StatusInternalServerError, c.logger)
+		return
+	}
+
+	data, etag, generatedAt, err := c.krlCache.get(revoked, c.signer)
+	if err != nil {
+		logHttpError(r, w, err, http.StatusInternalServerError, c.logger)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", generatedAt.UTC().Format(http.TimeFormat))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(data)
+}
+
+// get returns the cached KRL if it still reflects the given revoked set,
+// regenerating it otherwise.
+func (c *krlCache) get(revoked []storage.RevokedKey, signer ssh.Signer) (data []byte, etag string, generatedAt time.Time, err error) {
+	fingerprint := fingerprintRevoked(revoked)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.data != nil && c.forSerials == fingerprint {
+		return c.data, c.etag, c.generatedAt, nil
+	}
+
+	serials := make([]uint64, len(revoked))
+	for i, rk := range revoked {
+		serials[i] = rk.Serial
+	}
+
+	now := time.Now()
+	data, err = krl.Generate(signer, serials, now)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("generating KRL: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	c.data = data
+	c.etag = `"` + hex.EncodeToString(sum[:]) + `"`
+	c.generatedAt = now
+	c.forSerials = fingerprint
+
+	return c.data, c.etag, c.generatedAt, nil
+}
+
+func fingerprintRevoked(revoked []storage.RevokedKey) string {
+	h := sha256.New()
+	for _, rk := range revoked {
+		fmt.Fprintf(h, "%d:%s;", rk.Serial, rk.Reason)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
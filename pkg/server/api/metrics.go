@@ -0,0 +1,88 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/ssh"
+)
+
+// MetricsHandler serves the Prometheus exposition format for every metric
+// registered by this package, to be mounted at /metrics in server bootstrap.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// errorClass classifies why an issuance attempt failed, used as the "class"
+// label on sharkey_ca_error_total.
+type errorClass string
+
+const (
+	errClassBadPubkey      errorClass = "bad_pubkey"
+	errClassBadRequest     errorClass = "bad_request"
+	errClassStorageFailure errorClass = "storage_failure"
+	errClassSignFailure    errorClass = "sign_failure"
+	errClassAuthFailure    errorClass = "auth_failure"
+)
+
+// certTypeLabel renders an ssh.HostCert/ssh.UserCert constant as the "type"
+// label value used on all of the metrics below.
+func certTypeLabel(certType uint32) string {
+	switch certType {
+	case ssh.HostCert:
+		return "host"
+	case ssh.UserCert:
+		return "user"
+	default:
+		return "unknown"
+	}
+}
+
+var (
+	signedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sharkey_ca_signed_total",
+		Help: "Total number of certificates successfully signed by the CA.",
+	}, []string{"type"})
+
+	errorTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sharkey_ca_error_total",
+		Help: "Total number of failed issuance attempts, by error class.",
+	}, []string{"type", "class"})
+
+	signDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sharkey_sign_duration_seconds",
+		Help:    "Time taken to sign a certificate, measured within the signing step only.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"type", "outcome"})
+)
+
+// observeSignResult records the outcome of a signing attempt against the
+// Prometheus metrics declared above.
+func observeSignResult(certType uint32, elapsedSeconds float64, err error, class errorClass) {
+	typeLabel := certTypeLabel(certType)
+	if err != nil {
+		errorTotal.WithLabelValues(typeLabel, string(class)).Inc()
+		signDuration.WithLabelValues(typeLabel, "error").Observe(elapsedSeconds)
+		return
+	}
+	signedTotal.WithLabelValues(typeLabel).Inc()
+	signDuration.WithLabelValues(typeLabel, "success").Observe(elapsedSeconds)
+}
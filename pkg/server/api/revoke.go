@@ -0,0 +1,71 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// adminAuthenticated checks that the request presented a client certificate
+// whose CN is in c.conf.Admins, the same TLS-identified trust model Enroll
+// uses for hosts.
+func (c *Api) adminAuthenticated(r *http.Request) bool {
+	if !clientAuthenticated(r) {
+		return false
+	}
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	for _, admin := range c.conf.Admins {
+		if admin == cn {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoke handles POST /revoke/{serial}, adding the serial to the revocation
+// list consulted by KRL. The next call to /krl will include it.
+func (c *Api) Revoke(w http.ResponseWriter, r *http.Request) {
+	if !c.adminAuthenticated(r) {
+		logHttpError(r, w, errors.New("admin client certificate required"), http.StatusUnauthorized, c.logger)
+		return
+	}
+
+	serial, err := strconv.ParseUint(mux.Vars(r)["serial"], 10, 64)
+	if err != nil {
+		logHttpError(r, w, errors.New("serial must be a non-negative integer"), http.StatusBadRequest, c.logger)
+		return
+	}
+
+	reason := r.URL.Query().Get("reason")
+	if err := c.storage.Revoke(serial, reason); err != nil {
+		logHttpError(r, w, err, http.StatusInternalServerError, c.logger)
+		return
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"serial": serial,
+		"reason": reason,
+		"admin":  r.TLS.VerifiedChains[0][0].Subject.CommonName,
+	}).Info("cert revoked")
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,141 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/square/sharkey/pkg/server/config"
+	"github.com/square/sharkey/pkg/server/storage"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// memStorage is a minimal in-memory storage.Storage for exercising the
+// revoke/KRL handlers without a database.
+type memStorage struct {
+	mu      sync.Mutex
+	revoked []storage.RevokedKey
+}
+
+func (m *memStorage) RecordIssuance(certType uint32, identity string, pubkey ssh.PublicKey) (uint64, error) {
+	return 1, nil
+}
+
+func (m *memStorage) Revoke(serial uint64, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.revoked = append(m.revoked, storage.RevokedKey{Serial: serial, Reason: reason})
+	return nil
+}
+
+func (m *memStorage) ListRevoked() ([]storage.RevokedKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]storage.RevokedKey, len(m.revoked))
+	copy(out, m.revoked)
+	return out, nil
+}
+
+// adminRequest attaches a verified client certificate with the given CN to
+// req, as adminAuthenticated expects from TLS client-cert auth.
+func adminRequest(req *http.Request, cn string) *http.Request {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return req
+}
+
+func TestRevokeThenKRL(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating CA key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromSigner(priv)
+	if err != nil {
+		t.Fatalf("building ssh signer: %v", err)
+	}
+
+	store := &memStorage{}
+	c := New(&config.Config{Admins: []string{"admin"}}, store, signer, logrus.New(), nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/revoke/{serial}", c.Revoke).Methods("POST")
+	router.HandleFunc("/krl", c.KRL).Methods("GET")
+
+	revokeReq := adminRequest(httptest.NewRequest("POST", "/revoke/42", nil), "admin")
+	revokeRec := httptest.NewRecorder()
+	router.ServeHTTP(revokeRec, revokeReq)
+	if revokeRec.Code != http.StatusNoContent {
+		t.Fatalf("POST /revoke/42 = %d, want %d; body: %s", revokeRec.Code, http.StatusNoContent, revokeRec.Body)
+	}
+
+	krlReq := httptest.NewRequest("GET", "/krl", nil)
+	krlRec := httptest.NewRecorder()
+	router.ServeHTTP(krlRec, krlReq)
+	if krlRec.Code != http.StatusOK {
+		t.Fatalf("GET /krl = %d, want %d; body: %s", krlRec.Code, http.StatusOK, krlRec.Body)
+	}
+
+	etag := krlRec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET /krl response missing ETag")
+	}
+
+	cachedReq := httptest.NewRequest("GET", "/krl", nil)
+	cachedReq.Header.Set("If-None-Match", etag)
+	cachedRec := httptest.NewRecorder()
+	router.ServeHTTP(cachedRec, cachedReq)
+	if cachedRec.Code != http.StatusNotModified {
+		t.Fatalf("GET /krl with matching If-None-Match = %d, want %d", cachedRec.Code, http.StatusNotModified)
+	}
+}
+
+func TestRevokeRequiresAdmin(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating CA key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromSigner(priv)
+	if err != nil {
+		t.Fatalf("building ssh signer: %v", err)
+	}
+
+	store := &memStorage{}
+	c := New(&config.Config{Admins: []string{"admin"}}, store, signer, logrus.New(), nil)
+
+	req := adminRequest(httptest.NewRequest("POST", "/revoke/42", nil), "not-an-admin")
+	req = mux.SetURLVars(req, map[string]string{"serial": "42"})
+	rec := httptest.NewRecorder()
+	c.Revoke(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("Revoke from non-admin = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if revoked, _ := store.ListRevoked(); len(revoked) != 0 {
+		t.Fatalf("non-admin revoke should not have recorded anything, got %v", revoked)
+	}
+}
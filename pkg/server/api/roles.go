@@ -0,0 +1,158 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+// roleTemplateData is exposed as "." when rendering a Role's Principals
+// templates.
+type roleTemplateData struct {
+	User     string
+	Hostname string
+}
+
+// roleForHost returns the configured role whose Hostnames pattern matches
+// hostname, for use by signHost. c.conf.Roles is a map, so it's walked in
+// sorted-name order rather than range's randomized order: if two roles'
+// patterns both match the same host, the one earliest by name always wins,
+// instead of the choice varying from request to request.
+func (c *Api) roleForHost(hostname string) (config.Role, bool) {
+	for _, name := range sortedRoleNames(c.conf.Roles) {
+		role := c.conf.Roles[name]
+		for _, pattern := range role.Hostnames {
+			if ok, _ := path.Match(pattern, hostname); ok {
+				return role, true
+			}
+		}
+	}
+	return config.Role{}, false
+}
+
+// sortedRoleNames returns roles' keys in sorted order, so callers that need
+// to pick a single role out of several pattern matches do so deterministically.
+func sortedRoleNames(roles map[string]config.Role) []string {
+	names := make([]string, 0, len(roles))
+	for name := range roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// roleForUser resolves the role requested for EnrollUser. If roleName is
+// set, it must name a role that user is authorized for, and an error is
+// returned otherwise rather than silently falling back; if roleName is
+// empty, the first of the user's groups that also names an authorized role
+// is used, and (config.Role{}, false, nil) means no role applies at all.
+func (c *Api) roleForUser(roleName string, user string, groups []string) (config.Role, bool, error) {
+	if roleName != "" {
+		role, ok := c.conf.Roles[roleName]
+		if !ok {
+			return config.Role{}, false, fmt.Errorf("no such role %q", roleName)
+		}
+		if !roleAuthorized(role, user, groups) {
+			return config.Role{}, false, fmt.Errorf("not authorized for role %q", roleName)
+		}
+		return role, true, nil
+	}
+
+	for _, group := range groups {
+		if role, ok := c.conf.Roles[group]; ok && roleAuthorized(role, user, groups) {
+			return role, true, nil
+		}
+	}
+	return config.Role{}, false, nil
+}
+
+// roleAuthorized reports whether user or any of groups is named in role's
+// allow lists. A role with no allow lists is open to anyone authenticated.
+func roleAuthorized(role config.Role, user string, groups []string) bool {
+	if len(role.AuthorizedUsers) == 0 && len(role.AuthorizedGroups) == 0 {
+		return true
+	}
+	for _, u := range role.AuthorizedUsers {
+		if u == user {
+			return true
+		}
+	}
+	for _, g := range role.AuthorizedGroups {
+		for _, have := range groups {
+			if g == have {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renderPrincipals evaluates each of role.Principals as a text/template
+// against data, e.g. turning "{{.User}}-bastion" into "alice-bastion".
+func renderPrincipals(role config.Role, data roleTemplateData) ([]string, error) {
+	principals := make([]string, 0, len(role.Principals))
+	for _, tmplStr := range role.Principals {
+		tmpl, err := template.New("principal").Parse(tmplStr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing principal template %q: %w", tmplStr, err)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, fmt.Errorf("rendering principal template %q: %w", tmplStr, err)
+		}
+		principals = append(principals, out.String())
+	}
+	return principals, nil
+}
+
+// roleExtensions returns role.Extensions' keys, in the []string form sign
+// expects alongside any group- or config-derived extensions.
+func roleExtensions(role config.Role) []string {
+	extensions := make([]string, 0, len(role.Extensions))
+	for ext := range role.Extensions {
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
+
+// roleValidity parses a Role's ValidAfter/ValidBefore into a start-time
+// offset and an override for sign's default cert duration. An empty
+// ValidBefore leaves the duration override nil, so sign falls back to
+// getDurationForCertType.
+func roleValidity(role config.Role) (validAfterOffset time.Duration, durationOverride *time.Duration, err error) {
+	if role.ValidAfter != "" {
+		validAfterOffset, err = time.ParseDuration(role.ValidAfter)
+		if err != nil {
+			return 0, nil, fmt.Errorf("parsing valid_after %q: %w", role.ValidAfter, err)
+		}
+	}
+	if role.ValidBefore != "" {
+		duration, err := time.ParseDuration(role.ValidBefore)
+		if err != nil {
+			return 0, nil, fmt.Errorf("parsing valid_before %q: %w", role.ValidBefore, err)
+		}
+		durationOverride = &duration
+	}
+	return validAfterOffset, durationOverride, nil
+}
@@ -0,0 +1,48 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package api
+
+import (
+	"testing"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+// TestRoleForHostIsDeterministic checks that when more than one role's
+// Hostnames pattern matches the same host, roleForHost always picks the
+// same one rather than depending on Go's randomized map iteration order.
+func TestRoleForHostIsDeterministic(t *testing.T) {
+	conf := &config.Config{
+		Roles: map[string]config.Role{
+			"zebra":   {Hostnames: []string{"*.example.com"}, Extensions: map[string]string{"zebra-marker": ""}},
+			"alpaca":  {Hostnames: []string{"*.example.com"}, Extensions: map[string]string{"alpaca-marker": ""}},
+			"bastion": {Hostnames: []string{"bastion.example.com"}},
+		},
+	}
+	c := &Api{conf: conf}
+
+	for i := 0; i < 20; i++ {
+		role, ok := c.roleForHost("web.example.com")
+		if !ok {
+			t.Fatal("expected a role match")
+		}
+		// "alpaca" sorts before "zebra", so it must always be the winner.
+		if _, ok := role.Extensions["alpaca-marker"]; !ok {
+			t.Fatalf("expected the alphabetically-earliest matching role (alpaca) to win, got %+v", role)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config
+
+// Config is the top level configuration for the sharkey server.
+type Config struct {
+	ListenAddr     string     `yaml:"listen_addr"`
+	TLS            TLS        `yaml:"tls"`
+	Database       Database   `yaml:"database"`
+	SigningKey     SigningKey `yaml:"signing_key"`
+	AuthorityFor   []string   `yaml:"authority_for"`
+	KnownHostsFile string     `yaml:"known_hosts_file"`
+
+	HostCertDuration string `yaml:"host_cert_duration"`
+	UserCertDuration string `yaml:"user_cert_duration"`
+
+	// MaxUserCertValidity caps a validity duration requested via
+	// /enroll_user?validity=; a request exceeding it is clamped rather than
+	// rejected. Empty means requests may not override UserCertDuration at all.
+	MaxUserCertValidity string `yaml:"max_user_cert_validity"`
+
+	// StripSuffix is removed from a hostname to derive an additional principal.
+	StripSuffix string `yaml:"strip_suffix"`
+	// Aliases maps a hostname to additional principals to include on its cert.
+	Aliases map[string][]string `yaml:"aliases"`
+
+	SSH SSH `yaml:"ssh"`
+
+	// AuthenticatingProxy configures the legacy proxy-header trust model for user enrollment.
+	AuthenticatingProxy *AuthenticatingProxy `yaml:"authenticating_proxy"`
+
+	// OIDC configures bearer-token user authentication as an alternative to
+	// AuthenticatingProxy. The two may be configured together; EnrollUser
+	// prefers a bearer token when one is present on the request.
+	OIDC *OIDC `yaml:"oidc"`
+
+	// Groups maps an IdP group name (as reported by OIDC.GroupsClaim) to the
+	// principals and extensions granted to its members' certificates.
+	Groups map[string]GroupPermissions `yaml:"groups"`
+
+	// Admins lists the client certificate common names allowed to call the
+	// revocation endpoint.
+	Admins []string `yaml:"admins"`
+
+	// Roles names certificate templates that drive principals, extensions,
+	// critical options, and validity independently of the global SSH,
+	// StripSuffix, Aliases, and Groups settings above. signHost picks a role
+	// by matching Role.Hostnames against the requested hostname; EnrollUser
+	// picks one by name from a query parameter or an OIDC group.
+	Roles map[string]Role `yaml:"roles"`
+}
+
+// SSH holds settings that affect the content of issued certificates.
+type SSH struct {
+	UserCertExtensions []string `yaml:"user_cert_extensions"`
+}
+
+// AuthenticatingProxy identifies a reverse proxy that is trusted to assert a
+// username via an HTTP header, authenticated by its TLS client certificate.
+type AuthenticatingProxy struct {
+	Hostname       string `yaml:"hostname"`
+	UsernameHeader string `yaml:"username_header"`
+}
+
+// OIDC configures user authentication via a bearer JWT issued by an OAuth2/
+// OIDC identity provider, validated against its discovered JWKS.
+type OIDC struct {
+	IssuerURL        string   `yaml:"issuer_url"`
+	ClientID         string   `yaml:"client_id"`
+	ClientSecret     string   `yaml:"client_secret"`
+	AllowedAudiences []string `yaml:"allowed_audiences"`
+	UsernameClaim    string   `yaml:"username_claim"`
+	GroupsClaim      string   `yaml:"groups_claim"`
+}
+
+// GroupPermissions describes what a member of a given IdP group is granted
+// on an issued user certificate.
+type GroupPermissions struct {
+	Principals []string `yaml:"principals"`
+	Extensions []string `yaml:"extensions"`
+}
+
+// Role is a named certificate template: a set of principals, extensions,
+// critical options, and a validity window, restricted to a set of
+// authorized users or groups (for EnrollUser) or hostname patterns (for
+// signHost).
+//
+// Principals entries are text/template strings evaluated with "." set to a
+// struct exposing .User and .Hostname, e.g. "{{.User}}-bastion".
+type Role struct {
+	Principals []string `yaml:"principals"`
+
+	// Extensions map to ssh.Certificate's Permissions.Extensions, e.g.
+	// permit-port-forwarding. Only the keys are used: every extension is
+	// issued with an empty value, whatever value is configured here.
+	Extensions map[string]string `yaml:"extensions"`
+
+	// CriticalOptions map to ssh.Certificate's Permissions.CriticalOptions,
+	// e.g. force-command or source-address, whose values actually matter and
+	// whose presence restricts the cert rather than granting it capabilities.
+	CriticalOptions map[string]string `yaml:"critical_options"`
+
+	// ValidAfter offsets the certificate's start time from the moment of
+	// issuance; typically zero or slightly negative to absorb clock skew.
+	ValidAfter string `yaml:"valid_after"`
+	// ValidBefore is the certificate's validity duration from ValidAfter.
+	ValidBefore string `yaml:"valid_before"`
+
+	// AuthorizedUsers/AuthorizedGroups restrict who may request this role
+	// from EnrollUser. If both are empty, any authenticated user may.
+	AuthorizedUsers  []string `yaml:"authorized_users"`
+	AuthorizedGroups []string `yaml:"authorized_groups"`
+
+	// Hostnames are glob patterns (as matched by path.Match) used by
+	// signHost to pick a role for a given host certificate request.
+	Hostnames []string `yaml:"hostnames"`
+}
+
+// SigningKey selects and configures the backend that holds the CA private
+// key. Type picks which of the backend-specific fields is consulted; exactly
+// one should be set.
+type SigningKey struct {
+	Type string `yaml:"type"` // file | aws-kms | gcp-kms | vault | pkcs11
+
+	File   *FileSigningKey   `yaml:"file"`
+	AWSKMS *AWSKMSSigningKey `yaml:"aws_kms"`
+	GCPKMS *GCPKMSSigningKey `yaml:"gcp_kms"`
+	Vault  *VaultSigningKey  `yaml:"vault"`
+	PKCS11 *PKCS11SigningKey `yaml:"pkcs11"`
+}
+
+// FileSigningKey loads the CA private key directly from disk. This is the
+// default backend, matching sharkey's historical behavior.
+type FileSigningKey struct {
+	Path string `yaml:"path"`
+}
+
+// AWSKMSSigningKey signs using an asymmetric key held in AWS KMS; the raw
+// key material never leaves KMS.
+type AWSKMSSigningKey struct {
+	Region string `yaml:"region"`
+	KeyID  string `yaml:"key_id"`
+}
+
+// GCPKMSSigningKey signs using an asymmetric key version held in GCP Cloud KMS.
+type GCPKMSSigningKey struct {
+	KeyResourceID string `yaml:"key_resource_id"` // projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*
+}
+
+// VaultSigningKey signs using a key managed by HashiCorp Vault's Transit
+// secrets engine.
+type VaultSigningKey struct {
+	Address   string `yaml:"address"`
+	Token     string `yaml:"token"`
+	MountPath string `yaml:"mount_path"` // defaults to "transit"
+	KeyName   string `yaml:"key_name"`
+}
+
+// PKCS11SigningKey signs using a key held on a PKCS#11 HSM, e.g. a
+// YubiHSM2 or CloudHSM.
+type PKCS11SigningKey struct {
+	ModulePath string `yaml:"module_path"`
+	TokenLabel string `yaml:"token_label"`
+	Pin        string `yaml:"pin"`
+	KeyLabel   string `yaml:"key_label"`
+}
+
+// TLS configures the server's HTTPS listener.
+type TLS struct {
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+	CA   string `yaml:"ca"`
+}
+
+// Database configures the backing store used to record issuance history.
+type Database struct {
+	Type       string `yaml:"type"`
+	Connection string `yaml:"connection"`
+}
@@ -0,0 +1,110 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package krl builds an OpenSSH Key Revocation List, as described by
+// PROTOCOL.krl in the OpenSSH source tree, listing the serial numbers of
+// certificates sharkey has revoked.
+package krl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// krlMagic is KRL_MAGIC from PROTOCOL.krl: the 7 bytes "SSHKRL\n"
+	// followed by a trailing NUL.
+	krlMagic         = "SSHKRL\n\x00"
+	krlFormatVersion = uint32(1)
+
+	// sectionCertificates is KRL_SECTION_CERTIFICATES: a ca_key scoping the
+	// section, followed by one or more nested certificate subsections.
+	sectionCertificates = byte(1)
+
+	// certSectionSerialList is KRL_SECTION_CERT_SERIAL_LIST, nested inside a
+	// sectionCertificates body: a bare sequence of uint64 serials, with the
+	// count implied by the subsection's length.
+	certSectionSerialList = byte(0x20)
+)
+
+// Generate builds a KRL revoking revokedSerials, scoped to the certificates
+// issued by ca (so that serials revoked under a different CA never collide
+// with sharkey's own). The returned bytes are installed by hosts at
+// sshd_config's RevokedKeys path; trust in them comes from how they were
+// fetched (TLS to this server), the same as the rest of PROTOCOL.krl's
+// deployment model, so no signature is embedded in the file itself.
+func Generate(ca ssh.Signer, revokedSerials []uint64, generatedAt time.Time) ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteString(krlMagic)
+	if err := binary.Write(&out, binary.BigEndian, krlFormatVersion); err != nil {
+		return nil, err
+	}
+
+	header := struct {
+		KRLVersion    uint64
+		GeneratedDate uint64
+		Flags         uint64
+		Reserved      []byte
+		Comment       []byte
+	}{
+		KRLVersion:    uint64(generatedAt.Unix()),
+		GeneratedDate: uint64(generatedAt.Unix()),
+	}
+	out.Write(ssh.Marshal(header))
+
+	section, err := certificatesSection(ca.PublicKey(), revokedSerials)
+	if err != nil {
+		return nil, err
+	}
+	out.WriteByte(sectionCertificates)
+	if err := binary.Write(&out, binary.BigEndian, uint32(len(section))); err != nil {
+		return nil, err
+	}
+	out.Write(section)
+
+	return out.Bytes(), nil
+}
+
+// certificatesSection encodes a KRL_SECTION_CERTIFICATES body: caKey, an
+// empty reserved string, then a nested KRL_SECTION_CERT_SERIAL_LIST
+// subsection listing serials.
+func certificatesSection(caKey ssh.PublicKey, serials []uint64) ([]byte, error) {
+	var section bytes.Buffer
+	section.Write(ssh.Marshal(struct {
+		CAKey    []byte
+		Reserved []byte
+	}{
+		CAKey: caKey.Marshal(),
+	}))
+
+	var serialList bytes.Buffer
+	for _, serial := range serials {
+		if err := binary.Write(&serialList, binary.BigEndian, serial); err != nil {
+			return nil, err
+		}
+	}
+
+	section.WriteByte(certSectionSerialList)
+	if err := binary.Write(&section, binary.BigEndian, uint32(serialList.Len())); err != nil {
+		return nil, err
+	}
+	section.Write(serialList.Bytes())
+
+	return section.Bytes(), nil
+}
@@ -0,0 +1,74 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package krl
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TestGenerateRoundTripsThroughSSHKeygen builds a KRL and hands it to the
+// system ssh-keygen binary to parse, since that's the ultimate consumer of
+// this format and the easiest way to catch a PROTOCOL.krl mistake.
+func TestGenerateRoundTripsThroughSSHKeygen(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not available")
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating CA key: %v", err)
+	}
+	ca, err := ssh.NewSignerFromSigner(priv)
+	if err != nil {
+		t.Fatalf("building ssh signer: %v", err)
+	}
+
+	data, err := Generate(ca, []uint64{1, 5, 42}, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "sharkey-*.krl")
+	if err != nil {
+		t.Fatalf("creating temp file: %v", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		t.Fatalf("writing KRL: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("closing KRL: %v", err)
+	}
+
+	out, err := exec.Command("ssh-keygen", "-Q", "-l", "-f", f.Name()).CombinedOutput()
+	if err != nil {
+		t.Fatalf("ssh-keygen -Q -l rejected generated KRL: %v\n%s", err, out)
+	}
+
+	for _, serial := range []string{"serial: 1", "serial: 5", "serial: 42"} {
+		if !strings.Contains(string(out), serial) {
+			t.Errorf("ssh-keygen output missing %q:\n%s", serial, out)
+		}
+	}
+}
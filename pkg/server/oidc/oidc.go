@@ -0,0 +1,55 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package oidc builds the token verifier used to authenticate users via an
+// OAuth2/OIDC bearer token, as an alternative to the authenticating proxy.
+package oidc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/square/sharkey/pkg/server/config"
+
+	"github.com/coreos/go-oidc"
+)
+
+// NewVerifier discovers the issuer's JWKS and returns a verifier that checks
+// a bearer token's signature, issuer, and audience.
+func NewVerifier(ctx context.Context, conf *config.OIDC) (*oidc.IDTokenVerifier, error) {
+	if conf == nil {
+		return nil, fmt.Errorf("oidc: no configuration supplied")
+	}
+	if len(conf.AllowedAudiences) == 0 {
+		// SkipClientIDCheck below hands audience checking entirely to the
+		// caller (auth_oidc.go's audienceAllowed), since a config may list
+		// more than one acceptable audience. If AllowedAudiences were empty,
+		// audienceAllowed would reject every token, so no user could ever
+		// authenticate via OIDC. Refuse to start with a clear error instead.
+		return nil, fmt.Errorf("oidc: allowed_audiences must list at least one audience")
+	}
+
+	provider, err := oidc.NewProvider(ctx, conf.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: discovering provider %s: %w", conf.IssuerURL, err)
+	}
+
+	// Audience membership is checked by the caller against AllowedAudiences,
+	// since a config may list more than one acceptable audience.
+	return provider.Verifier(&oidc.Config{
+		SkipClientIDCheck: true,
+	}), nil
+}
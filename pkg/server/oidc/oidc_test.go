@@ -0,0 +1,33 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+func TestNewVerifierRequiresAllowedAudiences(t *testing.T) {
+	_, err := NewVerifier(context.Background(), &config.OIDC{
+		IssuerURL: "https://issuer.example.com",
+	})
+	if err == nil {
+		t.Fatal("expected an error when allowed_audiences is empty")
+	}
+}
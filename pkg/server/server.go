@@ -0,0 +1,66 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/square/sharkey/pkg/server/api"
+	"github.com/square/sharkey/pkg/server/config"
+	sharkeyoidc "github.com/square/sharkey/pkg/server/oidc"
+	"github.com/square/sharkey/pkg/server/signing"
+	"github.com/square/sharkey/pkg/server/storage"
+
+	coreoidc "github.com/coreos/go-oidc"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh"
+)
+
+// NewRouter builds the mux.Router the server listens with, wiring the CA's
+// issuance endpoints together with the /metrics endpoint used for scraping.
+// The CA's signing key is built from conf.SigningKey, so callers never
+// handle the raw key material themselves.
+func NewRouter(conf *config.Config, store storage.Storage, logger *logrus.Logger) (*mux.Router, error) {
+	key, err := signing.New(context.Background(), &conf.SigningKey)
+	if err != nil {
+		return nil, fmt.Errorf("building CA signing key: %w", err)
+	}
+	signer, err := ssh.NewSignerFromSigner(key)
+	if err != nil {
+		return nil, fmt.Errorf("building ssh signer: %w", err)
+	}
+
+	var oidcVerifier *coreoidc.IDTokenVerifier
+	if conf.OIDC != nil {
+		oidcVerifier, err = sharkeyoidc.NewVerifier(context.Background(), conf.OIDC)
+		if err != nil {
+			return nil, fmt.Errorf("building oidc verifier: %w", err)
+		}
+	}
+
+	a := api.New(conf, store, signer, logger, oidcVerifier)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/enroll/{hostname}", a.Enroll).Methods("POST")
+	r.HandleFunc("/enroll_user", a.EnrollUser).Methods("POST")
+	r.HandleFunc("/revoke/{serial}", a.Revoke).Methods("POST")
+	r.HandleFunc("/krl", a.KRL).Methods("GET")
+	r.Handle("/metrics", api.MetricsHandler()).Methods("GET")
+	return r, nil
+}
@@ -0,0 +1,100 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+
+	"github.com/square/sharkey/pkg/server/config"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// awsKMSSigner is a crypto.Signer backed by an asymmetric signing key held
+// in AWS KMS. The private key material never leaves KMS; Sign issues a
+// kms:Sign API call per signature.
+type awsKMSSigner struct {
+	client    *kms.Client
+	keyID     string
+	pub       crypto.PublicKey
+	algorithm types.SigningAlgorithmSpec
+}
+
+func newAWSKMSSigner(ctx context.Context, conf *config.AWSKMSSigningKey) (crypto.Signer, error) {
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(conf.Region))
+	if err != nil {
+		return nil, fmt.Errorf("aws-kms: loading AWS config: %w", err)
+	}
+	client := kms.NewFromConfig(awsCfg)
+
+	out, err := client.GetPublicKey(ctx, &kms.GetPublicKeyInput{KeyId: aws.String(conf.KeyID)})
+	if err != nil {
+		return nil, fmt.Errorf("aws-kms: fetching public key for %s: %w", conf.KeyID, err)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(out.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("aws-kms: parsing public key for %s: %w", conf.KeyID, err)
+	}
+	if err := requireSHA256CompatibleKey(pub); err != nil {
+		return nil, fmt.Errorf("aws-kms: %s: %w", conf.KeyID, err)
+	}
+
+	algorithm, err := signingAlgorithmFor(pub)
+	if err != nil {
+		return nil, fmt.Errorf("aws-kms: %w", err)
+	}
+
+	return &awsKMSSigner{client: client, keyID: conf.KeyID, pub: pub, algorithm: algorithm}, nil
+}
+
+func (s *awsKMSSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *awsKMSSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	out, err := s.client.Sign(context.Background(), &kms.SignInput{
+		KeyId:            aws.String(s.keyID),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: s.algorithm,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aws-kms: signing with %s: %w", s.keyID, err)
+	}
+	return out.Signature, nil
+}
+
+func signingAlgorithmFor(pub crypto.PublicKey) (types.SigningAlgorithmSpec, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return types.SigningAlgorithmSpecRsassaPkcs1V15Sha256, nil
+	case *ecdsa.PublicKey:
+		return types.SigningAlgorithmSpecEcdsaSha256, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
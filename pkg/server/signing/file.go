@@ -0,0 +1,63 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+// newFileSigner loads the CA private key directly off disk. This is
+// sharkey's original, default behavior, kept for operators who haven't
+// moved their key into a KMS or HSM.
+func newFileSigner(conf *config.FileSigningKey) (crypto.Signer, error) {
+	data, err := os.ReadFile(conf.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading signing key %s: %w", conf.Path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", conf.Path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q in %s", block.Type, conf.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing signing key %s: %w", conf.Path, err)
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s does not implement crypto.Signer", conf.Path)
+	}
+	return signer, nil
+}
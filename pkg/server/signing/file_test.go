@@ -0,0 +1,71 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+func TestNewFileSigner(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "ca.key")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("writing key: %v", err)
+	}
+
+	signer, err := newFileSigner(&config.FileSigningKey{Path: path})
+	if err != nil {
+		t.Fatalf("newFileSigner: %v", err)
+	}
+
+	if !signer.Public().(ed25519.PublicKey).Equal(pub) {
+		t.Fatal("signer's public key doesn't match the key written to disk")
+	}
+
+	sig, err := signer.Sign(rand.Reader, []byte("message"), crypto.Hash(0))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !ed25519.Verify(pub, []byte("message"), sig) {
+		t.Fatal("signature does not verify against the public key")
+	}
+}
+
+func TestNewFileSignerMissingFile(t *testing.T) {
+	_, err := newFileSigner(&config.FileSigningKey{Path: filepath.Join(t.TempDir(), "missing.key")})
+	if err == nil {
+		t.Fatal("expected an error for a nonexistent key file")
+	}
+}
@@ -0,0 +1,84 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"context"
+	"crypto"
+	"encoding/pem"
+	"fmt"
+	"io"
+
+	"github.com/square/sharkey/pkg/server/config"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	"cloud.google.com/go/kms/apiv1/kmspb"
+)
+
+// gcpKMSSigner is a crypto.Signer backed by a CryptoKeyVersion held in GCP
+// Cloud KMS.
+type gcpKMSSigner struct {
+	client        *kms.KeyManagementClient
+	keyResourceID string
+	pub           crypto.PublicKey
+}
+
+func newGCPKMSSigner(ctx context.Context, conf *config.GCPKMSSigningKey) (crypto.Signer, error) {
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcp-kms: creating client: %w", err)
+	}
+
+	resp, err := client.GetPublicKey(ctx, &kmspb.GetPublicKeyRequest{Name: conf.KeyResourceID})
+	if err != nil {
+		return nil, fmt.Errorf("gcp-kms: fetching public key for %s: %w", conf.KeyResourceID, err)
+	}
+
+	block, _ := pem.Decode([]byte(resp.Pem))
+	if block == nil {
+		return nil, fmt.Errorf("gcp-kms: no PEM data in public key for %s", conf.KeyResourceID)
+	}
+
+	pub, err := parsePublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("gcp-kms: parsing public key for %s: %w", conf.KeyResourceID, err)
+	}
+	if err := requireSHA256CompatibleKey(pub); err != nil {
+		return nil, fmt.Errorf("gcp-kms: %s: %w", conf.KeyResourceID, err)
+	}
+
+	return &gcpKMSSigner{client: client, keyResourceID: conf.KeyResourceID, pub: pub}, nil
+}
+
+func (s *gcpKMSSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *gcpKMSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	req := &kmspb.AsymmetricSignRequest{
+		Name: s.keyResourceID,
+		Digest: &kmspb.Digest{
+			Digest: &kmspb.Digest_Sha256{Sha256: digest},
+		},
+	}
+
+	resp, err := s.client.AsymmetricSign(context.Background(), req)
+	if err != nil {
+		return nil, fmt.Errorf("gcp-kms: signing with %s: %w", s.keyResourceID, err)
+	}
+	return resp.Signature, nil
+}
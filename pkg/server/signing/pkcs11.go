@@ -0,0 +1,56 @@
+//go:build pkcs11
+
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/square/sharkey/pkg/server/config"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// pkcs11Signer is a crypto.Signer backed by a key held on a PKCS#11 token
+// (a YubiHSM2, CloudHSM, etc). Building sharkey with this backend requires
+// the "pkcs11" build tag and a working PKCS#11 module + cgo toolchain.
+type pkcs11Signer struct {
+	crypto.Signer
+}
+
+func newPKCS11Signer(conf *config.PKCS11SigningKey) (crypto.Signer, error) {
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:       conf.ModulePath,
+		TokenLabel: conf.TokenLabel,
+		Pin:        conf.Pin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: initializing module %s: %w", conf.ModulePath, err)
+	}
+
+	signer, err := ctx.FindKeyPair(nil, []byte(conf.KeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: finding key %s: %w", conf.KeyLabel, err)
+	}
+	if signer == nil {
+		return nil, fmt.Errorf("pkcs11: no key labeled %q found on token %s", conf.KeyLabel, conf.TokenLabel)
+	}
+
+	return &pkcs11Signer{Signer: signer}, nil
+}
@@ -0,0 +1,32 @@
+//go:build !pkcs11
+
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+// newPKCS11Signer is stubbed out unless sharkey is built with the "pkcs11"
+// tag, since the real implementation needs cgo and a PKCS#11 module.
+func newPKCS11Signer(conf *config.PKCS11SigningKey) (crypto.Signer, error) {
+	return nil, fmt.Errorf("pkcs11: sharkey was built without PKCS#11 support (build with -tags pkcs11)")
+}
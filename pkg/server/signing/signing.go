@@ -0,0 +1,68 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package signing builds the crypto.Signer that holds the CA private key,
+// selecting among pluggable backends (a local file, a cloud KMS, Vault
+// Transit, or a PKCS#11 HSM) so the raw key need never sit on disk.
+package signing
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+// New builds the crypto.Signer selected by conf.Type. The caller typically
+// wraps the result with ssh.NewSignerFromSigner before handing it to the api
+// package.
+func New(ctx context.Context, conf *config.SigningKey) (crypto.Signer, error) {
+	switch conf.Type {
+	case "", "file":
+		if conf.File == nil {
+			return nil, fmt.Errorf("signing: file backend selected but signing_key.file is not configured")
+		}
+		return newFileSigner(conf.File)
+
+	case "aws-kms":
+		if conf.AWSKMS == nil {
+			return nil, fmt.Errorf("signing: aws-kms backend selected but signing_key.aws_kms is not configured")
+		}
+		return newAWSKMSSigner(ctx, conf.AWSKMS)
+
+	case "gcp-kms":
+		if conf.GCPKMS == nil {
+			return nil, fmt.Errorf("signing: gcp-kms backend selected but signing_key.gcp_kms is not configured")
+		}
+		return newGCPKMSSigner(ctx, conf.GCPKMS)
+
+	case "vault":
+		if conf.Vault == nil {
+			return nil, fmt.Errorf("signing: vault backend selected but signing_key.vault is not configured")
+		}
+		return newVaultSigner(conf.Vault)
+
+	case "pkcs11":
+		if conf.PKCS11 == nil {
+			return nil, fmt.Errorf("signing: pkcs11 backend selected but signing_key.pkcs11 is not configured")
+		}
+		return newPKCS11Signer(conf.PKCS11)
+
+	default:
+		return nil, fmt.Errorf("signing: unknown signing key type %q", conf.Type)
+	}
+}
@@ -0,0 +1,65 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// parsePublicKey parses a DER-encoded SubjectPublicKeyInfo, as returned by
+// the KMS/HSM backends' "give me the public key" calls.
+func parsePublicKey(der []byte) (crypto.PublicKey, error) {
+	return x509.ParsePKIXPublicKey(der)
+}
+
+// parseTransitPublicKey parses the PEM-encoded public key Vault's Transit
+// engine returns alongside a key's metadata.
+func parseTransitPublicKey(pemKey string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in transit public key")
+	}
+	return parsePublicKey(block.Bytes)
+}
+
+// requireSHA256CompatibleKey rejects any CA key that ssh.NewSignerFromSigner
+// would hash with something other than SHA-256 before calling Sign: the
+// KMS/Vault backends all hardcode a SHA-256 digest in their Sign methods
+// (Digest_Sha256, EcdsaSha256, Vault's sha2-256 default), so an ECDSA key on
+// a curve other than P-256 would be hashed with SHA-384/512 by the ssh
+// package but signed as if it were a SHA-256 digest, silently producing an
+// invalid signature. RSA keys are fine at any size, since SHA-256 is an
+// explicit, independent choice there rather than tied to the key itself.
+func requireSHA256CompatibleKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return fmt.Errorf("only P-256 ECDSA keys are supported (got curve %s); ssh.NewSignerFromSigner would hash with a different algorithm than this backend signs with", k.Curve.Params().Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
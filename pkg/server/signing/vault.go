@@ -0,0 +1,152 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/square/sharkey/pkg/server/config"
+
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// vaultSigner is a crypto.Signer backed by a key in Vault's Transit secrets
+// engine. Signing happens via the transit/sign API; the key never leaves
+// Vault.
+type vaultSigner struct {
+	client    *vaultapi.Client
+	mountPath string
+	keyName   string
+	pub       crypto.PublicKey
+}
+
+func newVaultSigner(conf *config.VaultSigningKey) (crypto.Signer, error) {
+	mountPath := conf.MountPath
+	if mountPath == "" {
+		mountPath = "transit"
+	}
+
+	vaultCfg := vaultapi.DefaultConfig()
+	vaultCfg.Address = conf.Address
+	client, err := vaultapi.NewClient(vaultCfg)
+	if err != nil {
+		return nil, fmt.Errorf("vault: building client: %w", err)
+	}
+	client.SetToken(conf.Token)
+
+	pub, err := fetchVaultPublicKey(client, mountPath, conf.KeyName)
+	if err != nil {
+		return nil, fmt.Errorf("vault: fetching public key for %s: %w", conf.KeyName, err)
+	}
+	if err := requireSHA256CompatibleKey(pub); err != nil {
+		return nil, fmt.Errorf("vault: %s: %w", conf.KeyName, err)
+	}
+
+	return &vaultSigner{client: client, mountPath: mountPath, keyName: conf.KeyName, pub: pub}, nil
+}
+
+func fetchVaultPublicKey(client *vaultapi.Client, mountPath, keyName string) (crypto.PublicKey, error) {
+	secret, err := client.Logical().Read(fmt.Sprintf("%s/keys/%s", mountPath, keyName))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("key %s not found", keyName)
+	}
+
+	keys, ok := secret.Data["keys"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected response shape reading key %s", keyName)
+	}
+
+	// Transit keys the map by version number ("1", "2", ...); the highest
+	// version is the one transit/sign actually signs with, so the public key
+	// we hand out must come from that same version or certs we issue won't
+	// verify against it.
+	var latestVersion int
+	var latest map[string]interface{}
+	for version, v := range keys {
+		n, err := strconv.Atoi(version)
+		if err != nil {
+			continue
+		}
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if latest == nil || n > latestVersion {
+			latestVersion = n
+			latest = m
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no key versions found for %s", keyName)
+	}
+
+	pemKey, _ := latest["public_key"].(string)
+	return parseTransitPublicKey(pemKey)
+}
+
+// vaultSignatureAlgorithm returns the "signature_algorithm" value transit/sign
+// expects for pub's key type, and whether the parameter applies at all:
+// Vault only accepts it for RSA keys (pkcs1v15 or pss) and rejects it for
+// ECDSA/Ed25519 keys, which sign with their one fixed algorithm.
+func vaultSignatureAlgorithm(pub crypto.PublicKey) (algorithm string, applicable bool) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return "pkcs1v15", true
+	case *ecdsa.PublicKey:
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+func (s *vaultSigner) Public() crypto.PublicKey {
+	return s.pub
+}
+
+func (s *vaultSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	data := map[string]interface{}{
+		"input":     base64.StdEncoding.EncodeToString(digest),
+		"prehashed": true,
+	}
+	if algorithm, ok := vaultSignatureAlgorithm(s.pub); ok {
+		data["signature_algorithm"] = algorithm
+	}
+
+	path := fmt.Sprintf("%s/sign/%s", s.mountPath, s.keyName)
+	secret, err := s.client.Logical().Write(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("vault: signing with %s: %w", s.keyName, err)
+	}
+
+	raw, _ := secret.Data["signature"].(string)
+	// Vault returns "vault:v<version>:<base64 signature>"
+	parts := strings.SplitN(raw, ":", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("vault: unexpected signature format %q", raw)
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
@@ -0,0 +1,145 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/square/sharkey/pkg/server/config"
+)
+
+// fakeVaultTransit is a minimal stand-in for Vault's Transit secrets engine,
+// just enough of it to exercise newVaultSigner/fetchVaultPublicKey/Sign:
+// GET .../keys/<name> and POST .../sign/<name>.
+type fakeVaultTransit struct {
+	key      *ecdsa.PrivateKey
+	versions map[string]string // version -> PEM public key
+}
+
+func newFakeVaultTransit(t *testing.T) *fakeVaultTransit {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("marshaling public key: %v", err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	// Register versions 1 and 2, out of map-iteration order on purpose, to
+	// make sure fetchVaultPublicKey picks the highest version rather than
+	// whichever one a random map walk lands on.
+	return &fakeVaultTransit{
+		key: key,
+		versions: map[string]string{
+			"1": "-----BEGIN PUBLIC KEY-----\nstale\n-----END PUBLIC KEY-----\n",
+			"2": pemKey,
+		},
+	}
+}
+
+func (f *fakeVaultTransit) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/transit/keys/ca", func(w http.ResponseWriter, r *http.Request) {
+		keys := make(map[string]interface{}, len(f.versions))
+		for version, pemKey := range f.versions {
+			keys[version] = map[string]interface{}{"public_key": pemKey}
+		}
+		writeVaultResponse(w, map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/v1/transit/sign/ca", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input              string `json:"input"`
+			Prehashed          bool   `json:"prehashed"`
+			SignatureAlgorithm string `json:"signature_algorithm"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// transit/sign only accepts signature_algorithm for RSA keys; an
+		// ECDSA key here should never receive one.
+		if body.SignatureAlgorithm != "" {
+			http.Error(w, "signature_algorithm not valid for this key type", http.StatusBadRequest)
+			return
+		}
+
+		digest, err := base64.StdEncoding.DecodeString(body.Input)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sig, err := ecdsa.SignASN1(rand.Reader, f.key, digest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeVaultResponse(w, map[string]interface{}{
+			"signature": fmt.Sprintf("vault:v2:%s", base64.StdEncoding.EncodeToString(sig)),
+		})
+	})
+	return mux
+}
+
+func writeVaultResponse(w http.ResponseWriter, data map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func TestVaultSignerUsesLatestKeyVersionAndECDSASigning(t *testing.T) {
+	fake := newFakeVaultTransit(t)
+	server := httptest.NewServer(fake.handler())
+	defer server.Close()
+
+	signer, err := newVaultSigner(&config.VaultSigningKey{
+		Address: server.URL,
+		Token:   "test-token",
+		KeyName: "ca",
+	})
+	if err != nil {
+		t.Fatalf("newVaultSigner: %v", err)
+	}
+
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		t.Fatalf("signer public key is %T, want *ecdsa.PublicKey", signer.Public())
+	}
+	if !pub.Equal(&fake.key.PublicKey) {
+		t.Fatal("signer fetched the stale version-1 public key instead of the latest version-2 key")
+	}
+
+	digest := []byte("0123456789abcdef0123456789abcdef") // 32 bytes, stand-in for a hash
+	sig, err := signer.Sign(nil, digest, nil)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !ecdsa.VerifyASN1(pub, digest, sig) {
+		t.Fatal("signature does not verify against the fetched public key")
+	}
+}
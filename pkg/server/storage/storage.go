@@ -0,0 +1,44 @@
+/*-
+ * Copyright 2016 Square Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package storage
+
+import (
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Storage records and retrieves the history of certificates issued by the CA.
+type Storage interface {
+	// RecordIssuance records that a certificate is about to be issued for the
+	// given identity and public key, returning the serial number to embed in it.
+	RecordIssuance(certType uint32, identity string, pubkey ssh.PublicKey) (uint64, error)
+
+	// Revoke marks a previously issued certificate's serial as revoked, to be
+	// included in the next generated KRL.
+	Revoke(serial uint64, reason string) error
+
+	// ListRevoked returns every revoked serial, for building a KRL.
+	ListRevoked() ([]RevokedKey, error)
+}
+
+// RevokedKey is one entry in the revocation list.
+type RevokedKey struct {
+	Serial    uint64
+	Reason    string
+	RevokedAt time.Time
+}